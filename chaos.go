@@ -1,9 +1,9 @@
 package main
 
 import (
-	"archive/zip"
 	"bufio"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
@@ -11,8 +11,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-
 	"strings"
+	"time"
 )
 
 func usage() {
@@ -23,50 +23,105 @@ USAGE:
     %s [OPTIONS]
 
 OPTIONS:
-    -c string    Comma-separated list of company names to download
-                 Example: -c "Tesla,Google,Microsoft"
-    -i string    Path to file containing company names (one per line)
+    -c string    Comma-separated list of company names/patterns to download
+                 Example: -c "Tesla,Google,Microsoft" or -c "tesla*,*bank*"
+    -i string    Path to file containing company names/patterns (one per line)
     -a           Download all available companies
+    -regex string
+                 Include companies whose (lowercased) name matches this regex;
+                 combined with -c/-i, a company matching either is included
+    -bounty      Only include companies with a bug bounty program
+    -platform string
+                 Only include companies on this bounty platform (e.g. hackerone)
+    -min-subdomains int
+                 Only include companies with at least this many known subdomains
+    -list        Print the filtered company list without downloading
+    -j int       Number of concurrent download workers (default 8)
+    -rps float   Max requests per second per host (default 2)
+    -timeout     Per-request HTTP timeout (default 30s)
+    -allow-symlinks
+                 Allow symlink entries in downloaded archives (default false)
+    -force       Bypass the manifest and re-download every company
+    -verify      Only re-check hashes of already-extracted data and report drift
+    -o string    Output format: txt, jsonl, sqlite, or stdout (default "txt")
+    -dedup       Drop subdomains already written, under any company
     -h           Show this help message
 
 EXAMPLES:
     # Download specific companies
     %s -c Tesla
     %s -c "Tesla,Google,Microsoft"
-    
+
+    # Download companies matching a glob or regex pattern
+    %s -c "tesla*,*bank*"
+    %s -regex "^(tesla|google)$"
+
     # Download companies from file
     %s -i companies.txt
-    
+
     # Download all available companies
     %s -a
 
+    # Preview a scope (bounty programs on HackerOne with 100+ subdomains)
+    # without downloading anything
+    %s -a -list -bounty -platform hackerone -min-subdomains 100
+
+    # Tune concurrency and rate limiting
+    %s -a -j 16 -rps 5 -timeout 60s
+
+    # Allow symlinks in archives, bypass the manifest, and verify
+    # previously extracted data against its recorded hash
+    %s -a -allow-symlinks -force
+    %s -verify
+
+    # Write deduplicated results to a SQLite database instead of a
+    # flat text file
+    %s -a -o sqlite -dedup
+
 DESCRIPTION:
     This tool downloads chaos data from Project Discovery for specified companies.
-    Downloaded data is extracted to ./AllChaosData/ and all .txt files are 
-    concatenated into everything.txt in the current directory.
+    .tar.gz archives are extracted as they download; .zip archives are
+    buffered in memory (or in a temp file when large) and then extracted.
+    Downloaded data is extracted to ./AllChaosData/, and every subdomain found
+    across all companies is written out in the format chosen with -o (default
+    everything.txt in the current directory).
+
+    A manifest at AllChaosData/.manifest.json records each company's ETag,
+    Last-Modified, size, and SHA-256 so repeat runs skip companies that
+    haven't changed server-side.
 
-`, progName, progName, progName, progName, progName)
+`, progName, progName, progName, progName, progName, progName, progName, progName, progName, progName, progName, progName)
 }
 
 func main() {
 	inputFile := flag.String("i", "", "Path to file containing company names (one per line)")
 	companies := flag.String("c", "", "Comma-separated list of company names to download (e.g., 'Tesla,Google,Microsoft')")
 	all := flag.Bool("a", false, "Download all available companies")
+	regexFlag := flag.String("regex", "", "Include companies whose (lowercased) name matches this regex; with -c/-i, a match on either is included")
+	bounty := flag.Bool("bounty", false, "Only include companies with a bug bounty program")
+	platform := flag.String("platform", "", "Only include companies on this bounty platform (e.g. hackerone)")
+	minSubdomains := flag.Int("min-subdomains", 0, "Only include companies with at least this many known subdomains")
+	list := flag.Bool("list", false, "Print the filtered company list without downloading")
+	jobs := flag.Int("j", 8, "Number of concurrent download workers")
+	rps := flag.Float64("rps", 2, "Max requests per second per host")
+	timeout := flag.Duration("timeout", 30*time.Second, "Per-request HTTP timeout")
+	allowSymlinks := flag.Bool("allow-symlinks", false, "Allow symlink entries in downloaded archives")
+	force := flag.Bool("force", false, "Bypass the manifest and re-download every company")
+	verify := flag.Bool("verify", false, "Only re-check hashes of already-extracted data and report drift")
+	output := flag.String("o", "txt", "Output format: txt, jsonl, sqlite, or stdout")
+	dedup := flag.Bool("dedup", false, "Drop subdomains already written, under any company, before writing")
 	help := flag.Bool("h", false, "Show usage information")
 	flag.Usage = usage
 	flag.Parse()
 
+	hasScope := *inputFile != "" || *companies != "" || *all || *regexFlag != "" || *bounty || *platform != "" || *minSubdomains > 0
+
 	// Show usage if no arguments provided or help requested
-	if *help || (*inputFile == "" && *companies == "" && !*all) {
+	if *help || (!hasScope && !*verify) {
 		flag.Usage()
 		return
 	}
 
-	baseDir := filepath.Join(".", "AllChaosData")
-	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
-		log.Fatalf("Failed to create base directory: %v", err)
-	}
-
 	var companySet map[string]struct{}
 	var err error
 
@@ -83,13 +138,47 @@ func main() {
 		fmt.Println("Downloading all available companies...")
 	}
 
+	filter, err := newCompanyFilter(companySet, *regexFlag, *bounty, *platform, *minSubdomains)
+	if err != nil {
+		log.Fatalf("Invalid filter: %v", err)
+	}
+
 	jsonURL := "https://chaos-data.projectdiscovery.io/index.json"
-	if err := processURLs(jsonURL, baseDir, companySet); err != nil {
+
+	if *list {
+		if err := listCompanies(jsonURL, filter); err != nil {
+			log.Fatalf("Failed to list companies: %v", err)
+		}
+		return
+	}
+
+	baseDir := filepath.Join(".", "AllChaosData")
+	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
+		log.Fatalf("Failed to create base directory: %v", err)
+	}
+
+	if *verify {
+		if err := verifyManifest(baseDir); err != nil {
+			log.Fatalf("Failed to verify manifest: %v", err)
+		}
+		return
+	}
+
+	if err := processURLs(jsonURL, baseDir, filter, *jobs, *rps, *timeout, *allowSymlinks, *force); err != nil {
 		log.Fatalf("Failed to process URLs: %v", err)
 	}
 
-	if err := concatenateAllTxtFiles(baseDir, "."); err != nil {
-		log.Fatalf("Failed to concatenate all txt files: %v", err)
+	sink, err := newOutputSink(*output, ".", *dedup)
+	if err != nil {
+		log.Fatalf("Failed to create output sink: %v", err)
+	}
+
+	if err := scanAndWrite(baseDir, sink); err != nil {
+		log.Fatalf("Failed to scan downloaded data: %v", err)
+	}
+
+	if err := sink.Close(); err != nil {
+		log.Fatalf("Failed to finalize output: %v", err)
 	}
 }
 
@@ -133,143 +222,80 @@ func readCompanyList(path string) (map[string]struct{}, error) {
 	return companies, scanner.Err()
 }
 
-func processURLs(jsonURL, baseDir string, filterSet map[string]struct{}) error {
-	resp, err := http.Get(jsonURL)
-	if err != nil {
-		return fmt.Errorf("error fetching JSON index: %w", err)
-	}
-	defer resp.Body.Close()
+func downloadAndUnzip(client *http.Client, m *manifest, url, name, baseDir string, allowSymlinks, force bool) error {
+	dirPath := filepath.Join(baseDir, name)
 
-	var entries []struct {
-		Name string `json:"name"`
-		URL  string `json:"URL"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
-		return fmt.Errorf("error decoding JSON index: %w", err)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("error building request for %s: %w", url, err)
 	}
 
-	processedCount := 0
-	for _, entry := range entries {
-		if filterSet != nil {
-			if _, ok := filterSet[strings.ToLower(entry.Name)]; !ok {
-				continue
+	// Only send conditional headers (and trust a resulting 304) when the
+	// previously extracted data is actually still on disk; otherwise a
+	// deleted/never-restored directory would be skipped forever.
+	if !force && dirHasData(dirPath) {
+		if prior, ok := m.get(name); ok && prior.URL == url {
+			if prior.ETag != "" {
+				req.Header.Set("If-None-Match", prior.ETag)
+			}
+			if prior.LastModified != "" {
+				req.Header.Set("If-Modified-Since", prior.LastModified)
 			}
-		}
-		fmt.Printf("Processing %s...\n", entry.Name)
-		if err := downloadAndUnzip(entry.URL, entry.Name, baseDir); err != nil {
-			log.Printf("Failed to process %s: %v\n", entry.Name, err)
-		} else {
-			processedCount++
 		}
 	}
 
-	fmt.Printf("\nCompleted processing %d companies.\n", processedCount)
-	return nil
-}
-
-func downloadAndUnzip(url, name, baseDir string) error {
-	resp, err := http.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error downloading %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
-	tempFile, err := os.CreateTemp("", "*.zip")
-	if err != nil {
-		return fmt.Errorf("error creating temp file: %w", err)
+	if resp.StatusCode == http.StatusNotModified {
+		fmt.Printf("%s unchanged since last run, skipping\n", name)
+		return nil
 	}
-	defer os.Remove(tempFile.Name())
 
-	if _, err = io.Copy(tempFile, resp.Body); err != nil {
-		tempFile.Close()
-		return fmt.Errorf("error writing to temp file: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{url: url, code: resp.StatusCode}
 	}
-	tempFile.Close()
 
-	dirPath := filepath.Join(baseDir, name)
+	// Clear any previously extracted data before re-extracting: without
+	// this, a file dropped from a newer archive would linger from the
+	// old extraction and get folded into ExtractedHash as if it were
+	// still legitimate, defeating -verify's drift detection.
+	if err := os.RemoveAll(dirPath); err != nil {
+		return fmt.Errorf("error clearing %s: %w", dirPath, err)
+	}
 	if err := os.MkdirAll(dirPath, os.ModePerm); err != nil {
 		return fmt.Errorf("error creating directory %s: %w", dirPath, err)
 	}
 
-	if err := unzipFile(tempFile.Name(), dirPath); err != nil {
-		return fmt.Errorf("error unzipping file: %w", err)
-	}
-
-	return nil
-}
-
-func unzipFile(zipFile, destDir string) error {
-	r, err := zip.OpenReader(zipFile)
-	if err != nil {
-		return fmt.Errorf("error opening zip file: %w", err)
-	}
-	defer r.Close()
-
-	for _, f := range r.File {
-		fpath := filepath.Join(destDir, f.Name)
+	counting := &countingReader{r: resp.Body}
+	hasher := sha256.New()
+	body := io.TeeReader(counting, hasher)
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
-			return err
-		}
-
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return fmt.Errorf("error opening output file: %w", err)
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			return fmt.Errorf("error opening zip content: %w", err)
-		}
-
-		_, err = io.Copy(outFile, rc)
-
-		outFile.Close()
-		rc.Close()
-
-		if err != nil {
-			return fmt.Errorf("error writing to output file: %w", err)
+	if isTarGzURL(url) {
+		if err := streamUntarGz(body, dirPath, allowSymlinks); err != nil {
+			return fmt.Errorf("error extracting tar.gz: %w", err)
 		}
+	} else if err := streamUnzip(body, dirPath, allowSymlinks); err != nil {
+		return fmt.Errorf("error unzipping file: %w", err)
 	}
-	return nil
-}
-
-func concatenateAllTxtFiles(baseDir, outputDir string) error {
-	allTxtFiles := findAllTxtFiles(baseDir)
 
-	destPath := filepath.Join(outputDir, "everything.txt")
-	dest, err := os.Create(destPath)
+	extractedHash, err := hashExtractedDir(dirPath)
 	if err != nil {
-		return fmt.Errorf("error creating %s: %w", destPath, err)
+		return fmt.Errorf("error hashing extracted data: %w", err)
 	}
-	defer dest.Close()
-
-	for _, file := range allTxtFiles {
-		src, err := os.Open(file)
-		if err != nil {
-			log.Printf("Failed to open %s for reading: %v", file, err)
-			continue
-		}
 
-		if _, err = io.Copy(dest, src); err != nil {
-			src.Close()
-			log.Printf("Failed to copy %s to %s: %v", file, destPath, err)
-			continue
-		}
-		src.Close()
-
-		if _, err = dest.WriteString("\n"); err != nil {
-			log.Printf("Failed to write newline after %s: %v", file, err)
-		}
-	}
+	m.set(name, manifestEntry{
+		URL:           url,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		Size:          counting.n,
+		SHA256:        hex.EncodeToString(hasher.Sum(nil)),
+		ExtractedHash: extractedHash,
+	})
 
-	fmt.Printf("Successfully created %s with all .txt file content.\n", destPath)
 	return nil
 }
 
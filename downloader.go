@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxRetries     = 3
+	retryBaseDelay = 500 * time.Millisecond
+)
+
+// httpStatusError records a non-2xx HTTP response so callers can decide
+// whether it's worth retrying.
+type httpStatusError struct {
+	url  string
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d fetching %s", e.code, e.url)
+}
+
+// isRetryableErr reports whether err is worth retrying: a 5xx response is,
+// any other HTTP status and an extractionError are not, and every other
+// error (network errors, but also local IO and archive-format errors) is
+// treated as retryable.
+func isRetryableErr(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500 && statusErr.code < 600
+	}
+	var extractErr *extractionError
+	if errors.As(err, &extractErr) {
+		return false
+	}
+	return true
+}
+
+// hostRateLimiter throttles outgoing requests so that no more than one
+// request every 1/rps seconds is issued to a given host.
+type hostRateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+}
+
+func newHostRateLimiter(rps float64) *hostRateLimiter {
+	l := &hostRateLimiter{last: make(map[string]time.Time)}
+	if rps > 0 {
+		l.interval = time.Duration(float64(time.Second) / rps)
+	}
+	return l
+}
+
+// wait blocks until it is safe to issue another request to host.
+func (l *hostRateLimiter) wait(host string) {
+	if l.interval <= 0 || host == "" {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	next := l.last[host].Add(l.interval)
+	var sleep time.Duration
+	if next.After(now) {
+		sleep = next.Sub(now)
+	}
+	l.last[host] = now.Add(sleep)
+	l.mu.Unlock()
+
+	if sleep > 0 {
+		time.Sleep(sleep)
+	}
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// progress tracks completed/failed counts across the worker pool so a
+// running summary can be printed as downloads finish.
+type progress struct {
+	total     int64
+	completed int64
+	failed    int64
+}
+
+func (p *progress) remaining() int64 {
+	return p.total - atomic.LoadInt64(&p.completed) - atomic.LoadInt64(&p.failed)
+}
+
+func (p *progress) print() {
+	fmt.Printf("\rProgress: %d completed, %d failed, %d remaining   ",
+		atomic.LoadInt64(&p.completed), atomic.LoadInt64(&p.failed), p.remaining())
+}
+
+// processURLs fetches the Chaos index, filters it against filter, and
+// fans the downloads out across jobs workers, each respecting the
+// shared per-host rate limit and per-request timeout.
+func processURLs(jsonURL, baseDir string, filter *companyFilter, jobs int, rps float64, timeout time.Duration, allowSymlinks, force bool) error {
+	entries, err := fetchIndex(jsonURL)
+	if err != nil {
+		return err
+	}
+
+	m, err := loadManifest(baseDir)
+	if err != nil {
+		return err
+	}
+
+	var selected []IndexEntry
+	for _, entry := range entries {
+		if filter.matches(entry) {
+			selected = append(selected, entry)
+		}
+	}
+
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	client := &http.Client{Timeout: timeout}
+	limiter := newHostRateLimiter(rps)
+	prog := &progress{total: int64(len(selected))}
+
+	jobsCh := make(chan IndexEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobsCh {
+				fmt.Printf("\nProcessing %s...\n", entry.Name)
+				if err := downloadAndUnzipWithRetry(client, limiter, m, entry.URL, entry.Name, baseDir, allowSymlinks, force); err != nil {
+					log.Printf("Failed to process %s: %v\n", entry.Name, err)
+					atomic.AddInt64(&prog.failed, 1)
+				} else {
+					atomic.AddInt64(&prog.completed, 1)
+				}
+				prog.print()
+			}
+		}()
+	}
+
+	for _, entry := range selected {
+		jobsCh <- entry
+	}
+	close(jobsCh)
+	wg.Wait()
+
+	if err := m.save(); err != nil {
+		log.Printf("Failed to save manifest: %v", err)
+	}
+
+	fmt.Printf("\n\nCompleted processing %d/%d companies (%d failed).\n",
+		atomic.LoadInt64(&prog.completed), prog.total, atomic.LoadInt64(&prog.failed))
+	return nil
+}
+
+// downloadAndUnzipWithRetry wraps downloadAndUnzip with exponential
+// backoff, retrying transient HTTP 5xx and network errors up to
+// maxRetries times.
+func downloadAndUnzipWithRetry(client *http.Client, limiter *hostRateLimiter, m *manifest, url, name, baseDir string, allowSymlinks, force bool) error {
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(math.Pow(2, float64(attempt-1))) * retryBaseDelay
+			time.Sleep(delay)
+		}
+
+		limiter.wait(hostOf(url))
+		err := downloadAndUnzip(client, m, url, name, baseDir, allowSymlinks, force)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !isRetryableErr(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", maxRetries+1, lastErr)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableErr(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"5xx status", &httpStatusError{url: "http://x", code: 500}, true},
+		{"4xx status", &httpStatusError{url: "http://x", code: 404}, false},
+		{"extraction error", &extractionError{entry: "evil.txt", reason: "path escapes destination directory"}, false},
+		{"generic error", errors.New("connection reset"), true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryableErr(tc.err); got != tc.want {
+				t.Fatalf("isRetryableErr(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHostRateLimiterWaitSpacesOutCalls(t *testing.T) {
+	limiter := newHostRateLimiter(10) // 100ms between requests to the same host
+
+	limiter.wait("example.com")
+	start := time.Now()
+	limiter.wait("example.com")
+	elapsed := time.Since(start)
+
+	if elapsed < 90*time.Millisecond {
+		t.Fatalf("second wait() for the same host returned after %v, want at least ~100ms", elapsed)
+	}
+}
+
+func TestHostRateLimiterWaitDoesNotThrottleDifferentHosts(t *testing.T) {
+	limiter := newHostRateLimiter(10)
+
+	limiter.wait("a.example.com")
+	start := time.Now()
+	limiter.wait("b.example.com")
+	elapsed := time.Since(start)
+
+	if elapsed > 50*time.Millisecond {
+		t.Fatalf("wait() for a different host took %v, want near-instant", elapsed)
+	}
+}
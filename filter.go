@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IndexEntry is a single row of the Chaos index.json
+// (https://chaos-data.projectdiscovery.io/index.json), covering the
+// full published schema rather than just the fields this tool
+// downloads by.
+type IndexEntry struct {
+	Name        string `json:"name"`
+	URL         string `json:"URL"`
+	Count       int    `json:"count"`
+	Bounty      bool   `json:"bounty"`
+	Platform    string `json:"platform"`
+	LastUpdated string `json:"last_updated"`
+}
+
+// fetchIndex downloads and decodes the Chaos index.json.
+func fetchIndex(jsonURL string) ([]IndexEntry, error) {
+	resp, err := http.Get(jsonURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching JSON index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var entries []IndexEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("error decoding JSON index: %w", err)
+	}
+	return entries, nil
+}
+
+// companyFilter decides whether an IndexEntry is in scope, combining an
+// exact/glob name allowlist (from -c/-i), an optional -regex pattern,
+// and field filters (-bounty, -platform, -min-subdomains). A
+// companyFilter with no name filter configured matches every entry that
+// passes the field filters, so -a combines naturally with -bounty etc.
+type companyFilter struct {
+	names         map[string]struct{} // exact lowercase names
+	globs         []string            // lowercase glob patterns, matched with path.Match
+	regex         *regexp.Regexp
+	bountyOnly    bool
+	platform      string
+	minSubdomains int
+}
+
+// newCompanyFilter builds a companyFilter from parsed flag values.
+// names is the company set from -c/-i (nil means "no name restriction");
+// entries containing glob metacharacters are treated as patterns rather
+// than exact names.
+func newCompanyFilter(names map[string]struct{}, regexPattern string, bountyOnly bool, platform string, minSubdomains int) (*companyFilter, error) {
+	f := &companyFilter{
+		bountyOnly:    bountyOnly,
+		platform:      strings.ToLower(platform),
+		minSubdomains: minSubdomains,
+	}
+
+	if names != nil {
+		f.names = make(map[string]struct{}, len(names))
+		for name := range names {
+			if strings.ContainsAny(name, "*?[") {
+				f.globs = append(f.globs, name)
+			} else {
+				f.names[name] = struct{}{}
+			}
+		}
+	}
+
+	if regexPattern != "" {
+		re, err := regexp.Compile(regexPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -regex pattern: %w", err)
+		}
+		f.regex = re
+	}
+
+	return f, nil
+}
+
+func (f *companyFilter) hasNameFilter() bool {
+	return f.names != nil || len(f.globs) > 0 || f.regex != nil
+}
+
+func (f *companyFilter) matchesName(lowerName string) bool {
+	if _, ok := f.names[lowerName]; ok {
+		return true
+	}
+	for _, pattern := range f.globs {
+		if ok, _ := path.Match(pattern, lowerName); ok {
+			return true
+		}
+	}
+	return f.regex != nil && f.regex.MatchString(lowerName)
+}
+
+// matches reports whether entry passes every configured filter.
+func (f *companyFilter) matches(entry IndexEntry) bool {
+	if f.hasNameFilter() && !f.matchesName(strings.ToLower(entry.Name)) {
+		return false
+	}
+	if f.bountyOnly && !entry.Bounty {
+		return false
+	}
+	if f.platform != "" && strings.ToLower(entry.Platform) != f.platform {
+		return false
+	}
+	if f.minSubdomains > 0 && entry.Count < f.minSubdomains {
+		return false
+	}
+	return true
+}
+
+// listCompanies prints every index entry that passes filter without
+// downloading anything, so users can preview a scope before pulling
+// gigabytes of archives.
+func listCompanies(jsonURL string, filter *companyFilter) error {
+	entries, err := fetchIndex(jsonURL)
+	if err != nil {
+		return err
+	}
+
+	matched := 0
+	for _, entry := range entries {
+		if !filter.matches(entry) {
+			continue
+		}
+		matched++
+		fmt.Printf("%-40s count=%-8d bounty=%-5t platform=%s\n", entry.Name, entry.Count, entry.Bounty, entry.Platform)
+	}
+
+	fmt.Printf("\n%d/%d companies match.\n", matched, len(entries))
+	return nil
+}
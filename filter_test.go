@@ -0,0 +1,98 @@
+package main
+
+import "testing"
+
+func mustCompanyFilter(t *testing.T, names map[string]struct{}, regex string, bountyOnly bool, platform string, minSubdomains int) *companyFilter {
+	t.Helper()
+	f, err := newCompanyFilter(names, regex, bountyOnly, platform, minSubdomains)
+	if err != nil {
+		t.Fatalf("newCompanyFilter: %v", err)
+	}
+	return f
+}
+
+func TestCompanyFilterNilMatchesEverything(t *testing.T) {
+	f := mustCompanyFilter(t, nil, "", false, "", 0)
+	if !f.matches(IndexEntry{Name: "anything"}) {
+		t.Fatal("expected an unfiltered companyFilter to match every entry")
+	}
+}
+
+func TestCompanyFilterExactName(t *testing.T) {
+	f := mustCompanyFilter(t, map[string]struct{}{"tesla": {}}, "", false, "", 0)
+	if !f.matches(IndexEntry{Name: "Tesla"}) {
+		t.Fatal("expected a case-insensitive exact name match")
+	}
+	if f.matches(IndexEntry{Name: "Google"}) {
+		t.Fatal("expected a non-matching name to be excluded")
+	}
+}
+
+func TestCompanyFilterGlob(t *testing.T) {
+	f := mustCompanyFilter(t, map[string]struct{}{"tesla*": {}, "*bank*": {}}, "", false, "", 0)
+	for _, name := range []string{"tesla", "teslamotors", "eurobank"} {
+		if !f.matches(IndexEntry{Name: name}) {
+			t.Errorf("expected %q to match a glob pattern", name)
+		}
+	}
+	if f.matches(IndexEntry{Name: "google"}) {
+		t.Fatal("expected a name matching no glob to be excluded")
+	}
+}
+
+func TestCompanyFilterRegex(t *testing.T) {
+	f := mustCompanyFilter(t, nil, "^tesla.*$", false, "", 0)
+	if !f.matches(IndexEntry{Name: "teslamotors"}) {
+		t.Fatal("expected the regex to match a prefixed name")
+	}
+	if f.matches(IndexEntry{Name: "nottesla"}) {
+		t.Fatal("expected the regex to reject a non-matching name")
+	}
+}
+
+func TestCompanyFilterEmptyNameSetMatchesNothing(t *testing.T) {
+	// A non-nil but empty name set (e.g. -c "," or an empty -i file) is still "has a name
+	// filter" per hasNameFilter, so it should exclude every company
+	// rather than silently falling back to "match all".
+	f := mustCompanyFilter(t, map[string]struct{}{}, "", false, "", 0)
+	if f.matches(IndexEntry{Name: "anything"}) {
+		t.Fatal("expected an empty (non-nil) name set to match nothing")
+	}
+}
+
+func TestCompanyFilterFieldFilters(t *testing.T) {
+	f := mustCompanyFilter(t, nil, "", true, "hackerone", 1000)
+
+	base := IndexEntry{Name: "ok", Bounty: true, Platform: "HackerOne", Count: 5000}
+	if !f.matches(base) {
+		t.Fatal("expected an entry passing every field filter to match")
+	}
+
+	noBounty := base
+	noBounty.Bounty = false
+	if f.matches(noBounty) {
+		t.Fatal("expected -bounty to exclude a non-bounty entry")
+	}
+
+	wrongPlatform := base
+	wrongPlatform.Platform = "bugcrowd"
+	if f.matches(wrongPlatform) {
+		t.Fatal("expected -platform to exclude a mismatched platform (case-insensitively)")
+	}
+
+	tooFewSubdomains := base
+	tooFewSubdomains.Count = 10
+	if f.matches(tooFewSubdomains) {
+		t.Fatal("expected -min-subdomains to exclude a low subdomain count")
+	}
+}
+
+func TestCompanyFilterNameAndFieldFiltersCombine(t *testing.T) {
+	f := mustCompanyFilter(t, map[string]struct{}{"tesla": {}}, "", true, "", 0)
+	if f.matches(IndexEntry{Name: "Tesla", Bounty: false}) {
+		t.Fatal("expected a name match without a bounty program to still be excluded")
+	}
+	if !f.matches(IndexEntry{Name: "Tesla", Bounty: true}) {
+		t.Fatal("expected a name match with a bounty program to pass")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const manifestFileName = ".manifest.json"
+
+// manifestEntry records everything needed to detect whether a company's
+// archive has changed since the last run.
+type manifestEntry struct {
+	URL           string `json:"url"`
+	ETag          string `json:"etag,omitempty"`
+	LastModified  string `json:"last_modified,omitempty"`
+	Size          int64  `json:"size"`
+	SHA256        string `json:"sha256"`
+	ExtractedHash string `json:"extracted_hash"`
+}
+
+// manifest is a concurrency-safe record of manifestEntry per company,
+// persisted to <baseDir>/.manifest.json between runs so repeat runs can
+// skip companies that haven't changed.
+type manifest struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]manifestEntry
+}
+
+func loadManifest(baseDir string) (*manifest, error) {
+	m := &manifest{
+		path:    filepath.Join(baseDir, manifestFileName),
+		entries: make(map[string]manifestEntry),
+	}
+
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m.entries); err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s: %w", m.path, err)
+	}
+	return m, nil
+}
+
+func (m *manifest) get(name string) (manifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[name]
+	return e, ok
+}
+
+func (m *manifest) set(name string, e manifestEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[name] = e
+}
+
+func (m *manifest) save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %w", err)
+	}
+	if err := os.WriteFile(m.path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing manifest %s: %w", m.path, err)
+	}
+	return nil
+}
+
+// countingReader wraps an io.Reader and tracks how many bytes have been
+// read through it, so the downloaded archive size can be recorded
+// without a separate pass over the data.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// dirHasData reports whether dir exists and contains at least one
+// entry. downloadAndUnzip uses this to avoid trusting a 304/manifest
+// hit when the previously extracted data has been deleted (disk
+// cleanup, partial restore, a manifest copied to a fresh machine).
+func dirHasData(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// hashExtractedDir returns a deterministic SHA-256 over the relative
+// path and contents of every non-directory entry beneath dir (symlinks
+// are hashed through to their target's contents), so drift in
+// already-extracted data can be detected without re-downloading.
+func hashExtractedDir(dir string) (string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == manifestFileName {
+			return nil
+		}
+		if !d.IsDir() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, rel := range paths {
+		fmt.Fprintf(h, "%s\x00", rel)
+		f, err := os.Open(filepath.Join(dir, rel))
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyManifest re-hashes every company directory recorded in the
+// manifest and reports any that no longer match what was recorded at
+// download time, without contacting the network.
+func verifyManifest(baseDir string) error {
+	m, err := loadManifest(baseDir)
+	if err != nil {
+		return err
+	}
+	if len(m.entries) == 0 {
+		fmt.Println("No manifest entries to verify.")
+		return nil
+	}
+
+	drifted := 0
+	for name, entry := range m.entries {
+		dirPath := filepath.Join(baseDir, name)
+		if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+			fmt.Printf("DRIFT  %s: extracted directory missing\n", name)
+			drifted++
+			continue
+		}
+
+		hash, err := hashExtractedDir(dirPath)
+		if err != nil {
+			return fmt.Errorf("error hashing %s: %w", name, err)
+		}
+		if hash != entry.ExtractedHash {
+			fmt.Printf("DRIFT  %s: extracted content hash mismatch\n", name)
+			drifted++
+		}
+	}
+
+	fmt.Printf("\nVerified %d companies, %d drifted.\n", len(m.entries), drifted)
+	return nil
+}
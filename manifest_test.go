@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirHasData(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(t *testing.T) string
+		want  bool
+	}{
+		{
+			name: "missing directory",
+			setup: func(t *testing.T) string {
+				return filepath.Join(t.TempDir(), "does-not-exist")
+			},
+			want: false,
+		},
+		{
+			name: "empty directory",
+			setup: func(t *testing.T) string {
+				return t.TempDir()
+			},
+			want: false,
+		},
+		{
+			name: "non-empty directory",
+			setup: func(t *testing.T) string {
+				dir := t.TempDir()
+				if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("x"), 0o644); err != nil {
+					t.Fatalf("writing fixture file: %v", err)
+				}
+				return dir
+			},
+			want: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := tc.setup(t)
+			if got := dirHasData(dir); got != tc.want {
+				t.Fatalf("dirHasData(%q) = %v, want %v", dir, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	baseDir := t.TempDir()
+
+	m, err := loadManifest(baseDir)
+	if err != nil {
+		t.Fatalf("loadManifest on empty dir: %v", err)
+	}
+	if _, ok := m.get("tesla"); ok {
+		t.Fatalf("expected no entry for %q in a fresh manifest", "tesla")
+	}
+
+	entry := manifestEntry{
+		URL:           "https://example.com/tesla.zip",
+		ETag:          `"abc123"`,
+		LastModified:  "Mon, 02 Jan 2006 15:04:05 GMT",
+		Size:          1024,
+		SHA256:        "deadbeef",
+		ExtractedHash: "cafef00d",
+	}
+	m.set("tesla", entry)
+
+	if err := m.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	reloaded, err := loadManifest(baseDir)
+	if err != nil {
+		t.Fatalf("loadManifest after save: %v", err)
+	}
+	got, ok := reloaded.get("tesla")
+	if !ok {
+		t.Fatalf("expected entry for %q after reload", "tesla")
+	}
+	if got != entry {
+		t.Fatalf("reloaded entry = %+v, want %+v", got, entry)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// recordingSink collects every (company, host) pair it receives, so tests
+// can assert on what a wrapping sink actually forwarded.
+type recordingSink struct {
+	writes [][2]string
+}
+
+func (s *recordingSink) Write(company, host string) error {
+	s.writes = append(s.writes, [2]string{company, host})
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestDedupSinkDropsDuplicates(t *testing.T) {
+	rec := &recordingSink{}
+	sink := newDedupSink(rec)
+
+	pairs := [][2]string{
+		{"tesla", "a.tesla.com"},
+		{"tesla", "b.tesla.com"},
+		{"tesla", "a.tesla.com"},
+		{"google", "a.tesla.com"},
+	}
+	for _, p := range pairs {
+		if err := sink.Write(p[0], p[1]); err != nil {
+			t.Fatalf("Write(%q, %q): %v", p[0], p[1], err)
+		}
+	}
+
+	// "a.tesla.com" is dropped the second time even though it shows up
+	// under a different company: dedup keys on host alone, since
+	// txt/stdout output discards company and would otherwise still emit
+	// the same subdomain twice.
+	want := [][2]string{
+		{"tesla", "a.tesla.com"},
+		{"tesla", "b.tesla.com"},
+	}
+	if len(rec.writes) != len(want) {
+		t.Fatalf("forwarded %d writes, want %d: got %v", len(rec.writes), len(want), rec.writes)
+	}
+	for i, w := range want {
+		if rec.writes[i] != w {
+			t.Fatalf("write %d = %v, want %v", i, rec.writes[i], w)
+		}
+	}
+}
+
+func TestJSONLSinkRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "everything.jsonl")
+
+	sink, err := newJSONLSink(path)
+	if err != nil {
+		t.Fatalf("newJSONLSink: %v", err)
+	}
+
+	pairs := [][2]string{
+		{"tesla", "a.tesla.com"},
+		{"google", "b.google.com"},
+	}
+	for _, p := range pairs {
+		if err := sink.Write(p[0], p[1]); err != nil {
+			t.Fatalf("Write(%q, %q): %v", p[0], p[1], err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var got []jsonlRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec jsonlRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("unmarshaling line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scanning %s: %v", path, err)
+	}
+
+	want := []jsonlRecord{
+		{Company: "tesla", Host: "a.tesla.com"},
+		{Company: "google", Host: "b.google.com"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d records, want %d: got %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isTarGzURL reports whether rawURL points at a .tar.gz/.tgz archive, as
+// opposed to the default .zip format.
+func isTarGzURL(rawURL string) bool {
+	p := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		p = u.Path
+	}
+	p = strings.ToLower(p)
+	return strings.HasSuffix(p, ".tar.gz") || strings.HasSuffix(p, ".tgz")
+}
+
+// streamUntarGz extracts a gzip-compressed tar archive entry-by-entry as
+// it's downloaded, applying the same path-traversal, symlink, and
+// size-limit checks as streamUnzip/unzipArchive. Nothing is buffered to
+// disk: both gzip and tar decoding run directly over the response body.
+//
+// Decompression itself is still single-threaded (compress/gzip): a
+// parallel gzip reader would need a non-stdlib dependency (e.g. pgzip)
+// for one archive format, which isn't worth it next to the disk-I/O win
+// streaming already gets us. Worker-pool concurrency across companies
+// (see processURLs) is where this repo parallelizes downloads instead.
+func streamUntarGz(body io.Reader, destDir string, allowSymlinks bool) error {
+	gzr, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("error opening gzip stream: %w", err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	var totalSize int64
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading tar entry: %w", err)
+		}
+
+		fpath, err := safeJoin(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink, tar.TypeLink:
+			if !allowSymlinks {
+				return &extractionError{entry: hdr.Name, reason: "symlink entries are not allowed (use -allow-symlinks)"}
+			}
+			if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+				return err
+			}
+			os.Remove(fpath)
+			if err := os.Symlink(hdr.Linkname, fpath); err != nil {
+				return fmt.Errorf("error creating symlink: %w", err)
+			}
+
+		case tar.TypeReg:
+			if err := extractTarEntry(tr, hdr, fpath, &totalSize); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractTarEntry(tr *tar.Reader, hdr *tar.Header, fpath string, totalSize *int64) error {
+	*totalSize += hdr.Size
+	if hdr.Size > maxEntrySize {
+		return &extractionError{entry: hdr.Name, reason: "entry exceeds per-entry size limit"}
+	}
+	if *totalSize > maxArchiveSize {
+		return &extractionError{entry: hdr.Name, reason: "archive exceeds total size limit"}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return err
+	}
+
+	outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(hdr.Mode))
+	if err != nil {
+		return fmt.Errorf("error opening output file: %w", err)
+	}
+	defer outFile.Close()
+
+	written, err := io.Copy(outFile, io.LimitReader(tr, maxEntrySize+1))
+	if err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if written > maxEntrySize {
+		return &extractionError{entry: hdr.Name, reason: "entry exceeds per-entry size limit"}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildTarGz writes a gzip-compressed tar archive to a byte slice from a
+// set of regular file entries and, optionally, a single symlink entry.
+func buildTarGz(t *testing.T, files map[string][]byte, symlink, symlinkTarget string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+
+	for name, data := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("writing header %q: %v", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatalf("writing entry %q: %v", name, err)
+		}
+	}
+
+	if symlink != "" {
+		hdr := &tar.Header{
+			Name:     symlink,
+			Typeflag: tar.TypeSymlink,
+			Linkname: symlinkTarget,
+			Mode:     0o777,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("writing symlink entry %q: %v", symlink, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("closing tar writer: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("closing gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestStreamUntarGzRejectsTraversal(t *testing.T) {
+	destDir := t.TempDir()
+	data := buildTarGz(t, map[string][]byte{"../evil.txt": []byte("pwned")}, "", "")
+
+	err := streamUntarGz(bytes.NewReader(data), destDir, false)
+	var extractErr *extractionError
+	if !errors.As(err, &extractErr) {
+		t.Fatalf("expected *extractionError for a traversal entry, got %v", err)
+	}
+}
+
+func TestStreamUntarGzRejectsSymlinksByDefault(t *testing.T) {
+	destDir := t.TempDir()
+	data := buildTarGz(t, nil, "link", "/etc/passwd")
+
+	err := streamUntarGz(bytes.NewReader(data), destDir, false)
+	var extractErr *extractionError
+	if !errors.As(err, &extractErr) {
+		t.Fatalf("expected *extractionError for a disallowed symlink, got %v", err)
+	}
+}
+
+func TestStreamUntarGzAllowsSymlinksWhenEnabled(t *testing.T) {
+	destDir := t.TempDir()
+	data := buildTarGz(t, nil, "link", "/etc/passwd")
+
+	if err := streamUntarGz(bytes.NewReader(data), destDir, true); err != nil {
+		t.Fatalf("expected symlink to be extracted with allowSymlinks=true: %v", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(destDir, "link"))
+	if err != nil {
+		t.Fatalf("reading extracted symlink: %v", err)
+	}
+	if target != "/etc/passwd" {
+		t.Fatalf("symlink target = %q, want %q", target, "/etc/passwd")
+	}
+}
+
+func TestStreamUntarGzEnforcesPerEntrySizeLimit(t *testing.T) {
+	oldLimit := maxEntrySize
+	maxEntrySize = 8
+	defer func() { maxEntrySize = oldLimit }()
+
+	destDir := t.TempDir()
+	data := buildTarGz(t, map[string][]byte{"big.txt": bytes.Repeat([]byte("a"), 1024)}, "", "")
+
+	err := streamUntarGz(bytes.NewReader(data), destDir, false)
+	var extractErr *extractionError
+	if !errors.As(err, &extractErr) {
+		t.Fatalf("expected *extractionError for an oversized entry, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Size limits are vars rather than consts so tests can shrink them
+// instead of allocating gigabyte-sized fixtures.
+var (
+	// maxEntrySize caps the decompressed size of any single archive entry.
+	maxEntrySize int64 = 1 << 30 // 1 GiB
+	// maxArchiveSize caps the total decompressed size of an archive.
+	maxArchiveSize int64 = 8 << 30 // 8 GiB
+	// maxInMemoryArchiveSize is the largest response body streamUnzip will
+	// buffer in memory instead of spilling to a temp file.
+	maxInMemoryArchiveSize int64 = 32 << 20 // 32 MiB
+)
+
+// extractionError reports that an archive entry failed a safety check
+// (path traversal, a disallowed symlink, or an oversized entry), as
+// opposed to an ordinary IO failure, so callers can tell a
+// malicious/corrupt archive apart from a transient filesystem error.
+type extractionError struct {
+	entry  string
+	reason string
+}
+
+func (e *extractionError) Error() string {
+	return fmt.Sprintf("unsafe archive entry %q: %s", e.entry, e.reason)
+}
+
+// safeJoin joins destDir and entryName, rejecting absolute paths and
+// entries whose cleaned path would escape destDir.
+func safeJoin(destDir, entryName string) (string, error) {
+	if filepath.IsAbs(entryName) {
+		return "", &extractionError{entry: entryName, reason: "absolute path"}
+	}
+
+	fpath := filepath.Join(destDir, entryName)
+	cleanDestDir := filepath.Clean(destDir)
+	if !strings.HasPrefix(filepath.Clean(fpath)+string(os.PathSeparator), cleanDestDir+string(os.PathSeparator)) {
+		return "", &extractionError{entry: entryName, reason: "path escapes destination directory"}
+	}
+	return fpath, nil
+}
+
+// streamUnzip extracts a zip archive read from body. Response bodies up
+// to maxInMemoryArchiveSize are buffered in memory so nothing hits disk
+// twice; larger ones spill to a seek-backed temp file (reusing whatever
+// was already buffered) since zip's central directory requires random
+// access that body alone can't provide.
+func streamUnzip(body io.Reader, destDir string, allowSymlinks bool) error {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, body, maxInMemoryArchiveSize+1)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("error downloading archive: %w", err)
+	}
+	if n <= maxInMemoryArchiveSize {
+		return unzipArchive(bytes.NewReader(buf.Bytes()), n, destDir, allowSymlinks)
+	}
+
+	tmp, err := os.CreateTemp("", "*.zip")
+	if err != nil {
+		return fmt.Errorf("error creating temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	written, err := io.Copy(tmp, io.MultiReader(&buf, body))
+	if err != nil {
+		return fmt.Errorf("error writing to temp file: %w", err)
+	}
+
+	return unzipArchive(tmp, written, destDir, allowSymlinks)
+}
+
+// unzipArchive extracts every entry of the zip archive backed by ra
+// (size bytes long) into destDir.
+func unzipArchive(ra io.ReaderAt, size int64, destDir string, allowSymlinks bool) error {
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return fmt.Errorf("error opening zip archive: %w", err)
+	}
+
+	var totalSize int64
+
+	for _, f := range r.File {
+		fpath, err := safeJoin(destDir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().Mode()&os.ModeSymlink != 0 && !allowSymlinks {
+			return &extractionError{entry: f.Name, reason: "symlink entries are not allowed (use -allow-symlinks)"}
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+
+		totalSize += int64(f.UncompressedSize64)
+		if int64(f.UncompressedSize64) > maxEntrySize {
+			return &extractionError{entry: f.Name, reason: "entry exceeds per-entry size limit"}
+		}
+		if totalSize > maxArchiveSize {
+			return &extractionError{entry: f.Name, reason: "archive exceeds total size limit"}
+		}
+
+		if err := extractZipEntry(f, fpath, allowSymlinks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractZipEntry writes a single non-directory zip entry to fpath,
+// enforcing maxEntrySize against the actual bytes copied in case the
+// entry's declared size lies (a classic zip-bomb trick).
+func extractZipEntry(f *zip.File, fpath string, allowSymlinks bool) error {
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("error opening zip content: %w", err)
+	}
+	defer rc.Close()
+
+	if f.FileInfo().Mode()&os.ModeSymlink != 0 {
+		// Symlink targets are stored as the entry's "content"; write the
+		// link itself rather than following it.
+		target, err := io.ReadAll(io.LimitReader(rc, maxEntrySize+1))
+		if err != nil {
+			return fmt.Errorf("error reading symlink target: %w", err)
+		}
+		os.Remove(fpath)
+		return os.Symlink(string(target), fpath)
+	}
+
+	outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return fmt.Errorf("error opening output file: %w", err)
+	}
+	defer outFile.Close()
+
+	written, err := io.Copy(outFile, io.LimitReader(rc, maxEntrySize+1))
+	if err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if written > maxEntrySize {
+		return &extractionError{entry: f.Name, reason: "entry exceeds per-entry size limit"}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	destDir := filepath.Join(string(os.PathSeparator), "dest")
+
+	cases := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{"plain nested file", "foo/bar.txt", false},
+		{"parent traversal", "../etc/passwd", true},
+		{"absolute path", "/etc/passwd", true},
+		{"traversal via nested dotdot", "foo/../../bar.txt", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := safeJoin(destDir, tc.entry)
+			if tc.wantErr && err == nil {
+				t.Fatalf("safeJoin(%q): expected error, got nil", tc.entry)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("safeJoin(%q): unexpected error: %v", tc.entry, err)
+			}
+			var extractErr *extractionError
+			if tc.wantErr && !errors.As(err, &extractErr) {
+				t.Fatalf("safeJoin(%q): expected *extractionError, got %T", tc.entry, err)
+			}
+		})
+	}
+}
+
+// buildZip writes a zip archive to a byte slice from a set of regular
+// file entries and, optionally, a single symlink entry.
+func buildZip(t *testing.T, files map[string][]byte, symlink, symlinkTarget string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	for name, data := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("creating entry %q: %v", name, err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			t.Fatalf("writing entry %q: %v", name, err)
+		}
+	}
+
+	if symlink != "" {
+		hdr := &zip.FileHeader{Name: symlink, Method: zip.Store}
+		hdr.SetMode(os.ModeSymlink | 0o777)
+		fw, err := w.CreateHeader(hdr)
+		if err != nil {
+			t.Fatalf("creating symlink entry %q: %v", symlink, err)
+		}
+		if _, err := fw.Write([]byte(symlinkTarget)); err != nil {
+			t.Fatalf("writing symlink target: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing zip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestUnzipArchiveRejectsTraversal(t *testing.T) {
+	destDir := t.TempDir()
+	data := buildZip(t, map[string][]byte{"../evil.txt": []byte("pwned")}, "", "")
+
+	err := unzipArchive(bytes.NewReader(data), int64(len(data)), destDir, false)
+	var extractErr *extractionError
+	if !errors.As(err, &extractErr) {
+		t.Fatalf("expected *extractionError for a traversal entry, got %v", err)
+	}
+}
+
+func TestUnzipArchiveRejectsSymlinksByDefault(t *testing.T) {
+	destDir := t.TempDir()
+	data := buildZip(t, nil, "link", "/etc/passwd")
+
+	err := unzipArchive(bytes.NewReader(data), int64(len(data)), destDir, false)
+	var extractErr *extractionError
+	if !errors.As(err, &extractErr) {
+		t.Fatalf("expected *extractionError for a disallowed symlink, got %v", err)
+	}
+}
+
+func TestUnzipArchiveAllowsSymlinksWhenEnabled(t *testing.T) {
+	destDir := t.TempDir()
+	data := buildZip(t, nil, "link", "/etc/passwd")
+
+	if err := unzipArchive(bytes.NewReader(data), int64(len(data)), destDir, true); err != nil {
+		t.Fatalf("expected symlink to be extracted with allowSymlinks=true: %v", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(destDir, "link"))
+	if err != nil {
+		t.Fatalf("reading extracted symlink: %v", err)
+	}
+	if target != "/etc/passwd" {
+		t.Fatalf("symlink target = %q, want %q", target, "/etc/passwd")
+	}
+}
+
+func TestUnzipArchiveEnforcesPerEntrySizeLimit(t *testing.T) {
+	oldLimit := maxEntrySize
+	maxEntrySize = 8
+	defer func() { maxEntrySize = oldLimit }()
+
+	destDir := t.TempDir()
+	data := buildZip(t, map[string][]byte{"big.txt": bytes.Repeat([]byte("a"), 1024)}, "", "")
+
+	err := unzipArchive(bytes.NewReader(data), int64(len(data)), destDir, false)
+	var extractErr *extractionError
+	if !errors.As(err, &extractErr) {
+		t.Fatalf("expected *extractionError for an oversized entry, got %v", err)
+	}
+}